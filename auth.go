@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var (
+	esAPIKey             = flag.String("es-api-key", "", "Elasticsearch API key (or set ES_API_KEY)")
+	esServiceToken       = flag.String("es-service-token", "", "Elasticsearch service token (or set ES_SERVICE_TOKEN)")
+	esCloudID            = flag.String("es-cloud-id", "", "Elastic Cloud ID (or set ES_CLOUD_ID)")
+	esCACertPath         = flag.String("es-ca-cert", "", "Path to a PEM-encoded CA certificate for the Elasticsearch cluster (or set ES_CA_CERT)")
+	esCAFingerprint      = flag.String("es-ca-fingerprint", "", "SHA256 fingerprint of the Elasticsearch CA certificate (or set ES_CA_FINGERPRINT)")
+	esInsecureSkipVerify = flag.Bool("es-insecure-skip-verify", false, "Skip TLS certificate verification when connecting to Elasticsearch (or set ES_INSECURE_SKIP_VERIFY)")
+)
+
+// applyEnvDefaults fills in flags left at their zero value from the standard
+// Elasticsearch environment variables, so the exporter can be deployed by a
+// Kubernetes operator without leaking credentials on the command line.
+func applyEnvDefaults() {
+	if *esURI == "" {
+		*esURI = os.Getenv("ES_URL")
+	}
+	if *esAPIKey == "" {
+		*esAPIKey = os.Getenv("ES_API_KEY")
+	}
+	if *esServiceToken == "" {
+		*esServiceToken = os.Getenv("ES_SERVICE_TOKEN")
+	}
+	if *esCloudID == "" {
+		*esCloudID = os.Getenv("ES_CLOUD_ID")
+	}
+	if *esCAFingerprint == "" {
+		*esCAFingerprint = os.Getenv("ES_CA_FINGERPRINT")
+	}
+	if *esCACertPath == "" {
+		*esCACertPath = os.Getenv("ES_CA_CERT")
+	}
+	if !*esInsecureSkipVerify {
+		if v, err := strconv.ParseBool(os.Getenv("ES_INSECURE_SKIP_VERIFY")); err == nil {
+			*esInsecureSkipVerify = v
+		}
+	}
+}
+
+// loadCACert reads a PEM-encoded CA certificate from path, returning nil if
+// path is empty.
+func loadCACert(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cert, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate %q: %w", path, err)
+	}
+	return cert, nil
+}
+
+// buildTLSConfig returns a *tls.Config for the exporter's custom transport.
+// It is never nil: go-elasticsearch's transport populates RootCAs on
+// whatever *tls.Config it's given, so a nil TLSClientConfig with --es-ca-cert
+// set would panic on a nil pointer dereference.
+func buildTLSConfig(insecureSkipVerify bool) *tls.Config {
+	return &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	upDesc = prometheus.NewDesc(
+		"elasticsearch_indices_up",
+		"Whether the Elasticsearch cluster was reachable on the last scrape (1) or not (0)",
+		nil, nil,
+	)
+	scrapeDurationHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "elasticsearch_indices_scrape_duration_seconds",
+		Help:    "Histogram of the duration of scrapes that actually queried Elasticsearch (cache hits within --query-interval aren't observed)",
+		Buckets: prometheus.DefBuckets,
+	})
+	indexExistsDesc = prometheus.NewDesc(
+		"elasticsearch_indices_exists",
+		"Whether an Elasticsearch index exists (1 if exists, 0 otherwise); always 1 outside the rule's expected window, and 0 if the index holds fewer than min_doc_count documents",
+		[]string{"index_name", "pattern", "rule", "severity", "expected"}, nil,
+	)
+	docsCountDesc = prometheus.NewDesc(
+		"elasticsearch_indices_docs_count",
+		"Number of documents in the index",
+		[]string{"index_name"}, nil,
+	)
+	docsDeletedDesc = prometheus.NewDesc(
+		"elasticsearch_indices_docs_deleted",
+		"Number of deleted documents in the index",
+		[]string{"index_name"}, nil,
+	)
+	storeSizeBytesDesc = prometheus.NewDesc(
+		"elasticsearch_indices_store_size_bytes",
+		"Size of the index store in bytes",
+		[]string{"index_name"}, nil,
+	)
+	primaryShardsDesc = prometheus.NewDesc(
+		"elasticsearch_indices_primary_shards",
+		"Number of primary shards for the index",
+		[]string{"index_name"}, nil,
+	)
+	replicaShardsDesc = prometheus.NewDesc(
+		"elasticsearch_indices_replica_shards",
+		"Number of replica shards for the index",
+		[]string{"index_name"}, nil,
+	)
+	healthDesc = prometheus.NewDesc(
+		"elasticsearch_indices_health",
+		"Index health (1 for the index's current color, 0 otherwise)",
+		[]string{"index_name", "color"}, nil,
+	)
+)
+
+// ruleResult is one rule's outcome for a single scrape.
+type ruleResult struct {
+	rule        *compiledRule
+	pattern     string
+	indexName   string
+	exists      bool
+	expected    bool
+	belowMinDoc bool
+}
+
+// scrapeResult is the outcome of a single round-trip to Elasticsearch,
+// cached by ESCollector so that scrapes arriving faster than cacheTTL
+// don't re-query the cluster.
+type scrapeResult struct {
+	up            bool
+	rules         []ruleResult
+	rows          []catIndexRow
+	scrapeSeconds float64
+}
+
+// ESCollector implements prometheus.Collector, querying Elasticsearch on
+// demand for each /metrics scrape instead of on a fixed ticker, so values are
+// never older than cacheTTL and idle exporters don't poll ES at all.
+type ESCollector struct {
+	client     *elasticsearch.Client
+	rules      []*compiledRule
+	dateOffset time.Duration
+	env        string
+	timeout    time.Duration
+	cacheTTL   time.Duration
+
+	mu       sync.Mutex
+	cachedAt time.Time
+	cached   *scrapeResult
+}
+
+func NewESCollector(client *elasticsearch.Client, rules []*compiledRule, dateOffset time.Duration, env string, timeout, cacheTTL time.Duration) *ESCollector {
+	return &ESCollector{
+		client:     client,
+		rules:      rules,
+		dateOffset: dateOffset,
+		env:        env,
+		timeout:    timeout,
+		cacheTTL:   cacheTTL,
+	}
+}
+
+func (c *ESCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- scrapeDurationHistogram.Desc()
+	ch <- indexExistsDesc
+	ch <- docsCountDesc
+	ch <- docsDeletedDesc
+	ch <- storeSizeBytesDesc
+	ch <- primaryShardsDesc
+	ch <- replicaShardsDesc
+	ch <- healthDesc
+}
+
+func (c *ESCollector) Collect(ch chan<- prometheus.Metric) {
+	result := c.scrape()
+
+	ch <- scrapeDurationHistogram
+
+	upValue := 0.0
+	if result.up {
+		upValue = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, upValue)
+	if !result.up {
+		return
+	}
+
+	for _, rr := range result.rules {
+		existsValue := 1.0
+		if rr.expected {
+			existsValue = 0.0
+			if rr.exists && !rr.belowMinDoc {
+				existsValue = 1.0
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(
+			indexExistsDesc, prometheus.GaugeValue, existsValue,
+			rr.indexName, rr.pattern, rr.rule.name, rr.rule.severity, strconv.FormatBool(rr.expected),
+		)
+	}
+
+	for _, row := range result.rows {
+		if docsCount, err := strconv.ParseFloat(row.DocsCount, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(docsCountDesc, prometheus.GaugeValue, docsCount, row.Index)
+		}
+		if docsDeleted, err := strconv.ParseFloat(row.DocsDeleted, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(docsDeletedDesc, prometheus.GaugeValue, docsDeleted, row.Index)
+		}
+		if storeSize, err := strconv.ParseFloat(row.StoreSize, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(storeSizeBytesDesc, prometheus.GaugeValue, storeSize, row.Index)
+		}
+		if pri, err := strconv.ParseFloat(row.Pri, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(primaryShardsDesc, prometheus.GaugeValue, pri, row.Index)
+		}
+		if rep, err := strconv.ParseFloat(row.Rep, 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(replicaShardsDesc, prometheus.GaugeValue, rep, row.Index)
+		}
+		for _, color := range []string{"green", "yellow", "red"} {
+			value := 0.0
+			if row.Health == color {
+				value = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, value, row.Index, color)
+		}
+	}
+}
+
+// statsPatterns returns the deduplicated, wildcarded index patterns that the
+// _cat/indices-based metrics should be scoped to, derived from the
+// configured rules rather than a separate --es-index-prefix.
+func (c *ESCollector) statsPatterns() []string {
+	seen := make(map[string]bool, len(c.rules))
+	var patterns []string
+	for _, rule := range c.rules {
+		p := statsIndexPattern(rule.pattern)
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// scrape queries Elasticsearch, reusing the previous result if it is still
+// within cacheTTL so repeated scrapes within --query-interval don't each
+// trigger a round-trip to the cluster.
+func (c *ESCollector) scrape() *scrapeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && c.cacheTTL > 0 && time.Since(c.cachedAt) < c.cacheTTL {
+		return c.cached
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	now := time.Now()
+	result := &scrapeResult{}
+
+	up := true
+	for _, rule := range c.rules {
+		indexName, err := renderIndexPattern(rule.pattern, rule.timezone, c.dateOffset, c.env, now)
+		if err != nil {
+			log.Printf("Error rendering index pattern for rule %q: %s", rule.name, err)
+			continue
+		}
+
+		exists, err := checkIndexExists(ctx, c.client, indexName)
+		if err != nil {
+			log.Printf("Error checking index for rule %q: %s", rule.name, err)
+			up = false
+			continue
+		}
+
+		rr := ruleResult{
+			rule:      rule,
+			pattern:   rule.pattern,
+			indexName: indexName,
+			exists:    exists,
+			expected:  rule.inExpectedWindow(now),
+		}
+
+		if exists && rule.minDocCount != nil {
+			docCount, found, err := indexDocCount(ctx, c.client, indexName)
+			if err != nil {
+				log.Printf("Error fetching doc count for rule %q: %s", rule.name, err)
+			} else if found && docCount < *rule.minDocCount {
+				rr.belowMinDoc = true
+			}
+		}
+
+		result.rules = append(result.rules, rr)
+	}
+	result.up = up
+
+	rows, err := collectIndexStats(ctx, c.client, c.statsPatterns())
+	if err != nil {
+		log.Printf("Error collecting index stats: %s", err)
+	} else {
+		result.rows = rows
+	}
+
+	result.scrapeSeconds = time.Since(start).Seconds()
+	scrapeDurationHistogram.Observe(result.scrapeSeconds)
+	c.cached = result
+	c.cachedAt = time.Now()
+	return result
+}
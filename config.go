@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TimeRange is an HH:MM-HH:MM window during which a rule's index is expected
+// to exist, optionally restricted to a set of weekdays (e.g. "mon", "tue").
+// An empty Days list means every day.
+type TimeRange struct {
+	Start string   `yaml:"start"`
+	End   string   `yaml:"end"`
+	Days  []string `yaml:"days,omitempty"`
+}
+
+// Rule describes one index family to watch: how its index names are built,
+// when it's expected to exist, and how to label alerts raised for it.
+type Rule struct {
+	Name            string      `yaml:"name"`
+	IndexPattern    string      `yaml:"index_pattern"`
+	Timezone        string      `yaml:"timezone"`
+	ExpectedBetween []TimeRange `yaml:"expected_between"`
+	Severity        string      `yaml:"severity"`
+	MinDocCount     *int64      `yaml:"min_doc_count"`
+}
+
+// Config is the top-level shape of --config.file.
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// LoadConfig reads and parses a YAML rules file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %q: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %q: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+var weekdaysByName = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// expectedWindow is a TimeRange after its HH:MM bounds and weekday names
+// have been parsed.
+type expectedWindow struct {
+	start time.Time
+	end   time.Time
+	days  map[time.Weekday]bool
+}
+
+func compileExpectedWindow(r TimeRange) (expectedWindow, error) {
+	start, err := parseTime(r.Start)
+	if err != nil {
+		return expectedWindow{}, fmt.Errorf("invalid expected_between start: %w", err)
+	}
+	end, err := parseTime(r.End)
+	if err != nil {
+		return expectedWindow{}, fmt.Errorf("invalid expected_between end: %w", err)
+	}
+
+	var days map[time.Weekday]bool
+	if len(r.Days) > 0 {
+		days = make(map[time.Weekday]bool, len(r.Days))
+		for _, name := range r.Days {
+			weekday, ok := weekdaysByName[strings.ToLower(name)]
+			if !ok {
+				return expectedWindow{}, fmt.Errorf("invalid weekday %q in expected_between", name)
+			}
+			days[weekday] = true
+		}
+	}
+
+	return expectedWindow{start: start, end: end, days: days}, nil
+}
+
+// compiledRule is a Rule after its timezone, windows, and index pattern have
+// been resolved into the types the collector needs at scrape time.
+type compiledRule struct {
+	name        string
+	pattern     string
+	timezone    *time.Location
+	expected    []expectedWindow
+	severity    string
+	minDocCount *int64
+}
+
+// compileRule resolves a Rule against the exporter's global defaults
+// (timezone), falling back to defaultTimezone when Rule.Timezone is unset.
+func compileRule(r Rule, defaultTimezone *time.Location) (*compiledRule, error) {
+	timezone := defaultTimezone
+	if r.Timezone != "" {
+		tz, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid timezone: %w", r.Name, err)
+		}
+		timezone = tz
+	}
+
+	windows := make([]expectedWindow, 0, len(r.ExpectedBetween))
+	for _, tr := range r.ExpectedBetween {
+		w, err := compileExpectedWindow(tr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		windows = append(windows, w)
+	}
+
+	return &compiledRule{
+		name:        r.Name,
+		pattern:     r.IndexPattern,
+		timezone:    timezone,
+		expected:    windows,
+		severity:    r.Severity,
+		minDocCount: r.MinDocCount,
+	}, nil
+}
+
+// compileRules resolves every rule in cfg against defaultTimezone.
+func compileRules(cfg *Config, defaultTimezone *time.Location) ([]*compiledRule, error) {
+	rules := make([]*compiledRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		compiled, err := compileRule(r, defaultTimezone)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+// legacyRules synthesizes one rule per pattern from --index-pattern (or the
+// default built from --es-index-prefix) and the global --start-time/--end-time
+// window, so --config.file remains optional.
+func legacyRules(patterns []string, timezone *time.Location, startTime, endTime time.Time) []*compiledRule {
+	rules := make([]*compiledRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, &compiledRule{
+			name:     "default",
+			pattern:  pattern,
+			timezone: timezone,
+			expected: []expectedWindow{{start: startTime, end: endTime}},
+		})
+	}
+	return rules
+}
+
+// inExpectedWindow reports whether now falls inside one of the rule's
+// expected_between windows. A rule with no windows is always expected.
+func (r *compiledRule) inExpectedWindow(now time.Time) bool {
+	if len(r.expected) == 0 {
+		return true
+	}
+
+	local := now.In(r.timezone)
+	weekday := local.Weekday()
+	current, err := parseTime(local.Format("15:04"))
+	if err != nil {
+		return true
+	}
+
+	for _, w := range r.expected {
+		if len(w.days) > 0 && !w.days[weekday] {
+			continue
+		}
+		if !current.Before(w.start) && !current.After(w.end) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompileRules(t *testing.T) {
+	utc, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("failed to load UTC: %v", err)
+	}
+
+	minDocs := int64(10)
+	cfg := &Config{
+		Rules: []Rule{
+			{
+				Name:         "business-hours",
+				IndexPattern: `myapp-{{.Date "2006.01.02"}}`,
+				ExpectedBetween: []TimeRange{
+					{Start: "09:00", End: "18:00", Days: []string{"mon", "Tuesday"}},
+				},
+				Severity:    "page",
+				MinDocCount: &minDocs,
+			},
+			{
+				Name:         "own-timezone",
+				IndexPattern: "otherapp-logs",
+				Timezone:     "America/New_York",
+			},
+		},
+	}
+
+	rules, err := compileRules(cfg, utc)
+	if err != nil {
+		t.Fatalf("compileRules returned error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("compileRules returned %d rules, want 2", len(rules))
+	}
+
+	if rules[0].timezone != utc {
+		t.Errorf("rule 0 timezone = %v, want default %v", rules[0].timezone, utc)
+	}
+	if rules[0].minDocCount == nil || *rules[0].minDocCount != minDocs {
+		t.Errorf("rule 0 minDocCount = %v, want %d", rules[0].minDocCount, minDocs)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load America/New_York: %v", err)
+	}
+	if rules[1].timezone.String() != ny.String() {
+		t.Errorf("rule 1 timezone = %v, want %v", rules[1].timezone, ny)
+	}
+}
+
+func TestCompileRulesInvalidWeekday(t *testing.T) {
+	utc, _ := time.LoadLocation("UTC")
+	cfg := &Config{
+		Rules: []Rule{
+			{
+				Name:            "bad-day",
+				IndexPattern:    "myapp-logs",
+				ExpectedBetween: []TimeRange{{Start: "09:00", End: "18:00", Days: []string{"someday"}}},
+			},
+		},
+	}
+
+	if _, err := compileRules(cfg, utc); err == nil {
+		t.Fatal("compileRules with an invalid weekday name returned no error")
+	}
+}
+
+func TestInExpectedWindow(t *testing.T) {
+	utc, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("failed to load UTC: %v", err)
+	}
+
+	cfg := &Config{
+		Rules: []Rule{
+			{
+				Name:         "weekday-afternoon",
+				IndexPattern: "myapp-logs",
+				ExpectedBetween: []TimeRange{
+					{Start: "09:00", End: "18:00", Days: []string{"mon", "tue", "wed", "thu", "fri"}},
+				},
+			},
+		},
+	}
+	rules, err := compileRules(cfg, utc)
+	if err != nil {
+		t.Fatalf("compileRules returned error: %v", err)
+	}
+	rule := rules[0]
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{
+			name: "weekday within window",
+			now:  time.Date(2026, time.July, 27, 12, 0, 0, 0, utc), // Monday
+			want: true,
+		},
+		{
+			name: "weekday before window",
+			now:  time.Date(2026, time.July, 27, 8, 0, 0, 0, utc),
+			want: false,
+		},
+		{
+			name: "weekend is never expected",
+			now:  time.Date(2026, time.July, 25, 12, 0, 0, 0, utc), // Saturday
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.inExpectedWindow(tt.now); got != tt.want {
+				t.Errorf("inExpectedWindow(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInExpectedWindowNoWindowsAlwaysExpected(t *testing.T) {
+	utc, _ := time.LoadLocation("UTC")
+	rule := &compiledRule{timezone: utc}
+	if !rule.inExpectedWindow(time.Date(2026, time.July, 25, 3, 0, 0, 0, utc)) {
+		t.Error("a rule with no expected_between windows should always be expected")
+	}
+}
+
+func TestLegacyRules(t *testing.T) {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC)
+
+	rules := legacyRules([]string{"a-*", "b-*"}, utc, start, end)
+	if len(rules) != 2 {
+		t.Fatalf("legacyRules returned %d rules, want 2", len(rules))
+	}
+	for i, pattern := range []string{"a-*", "b-*"} {
+		if rules[i].pattern != pattern {
+			t.Errorf("rule %d pattern = %q, want %q", i, rules[i].pattern, pattern)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// stringSliceFlag collects repeated occurrences of a flag, e.g.
+// --index-pattern a --index-pattern b.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// indexPatternData is the value exposed to --index-pattern templates, e.g.
+// `myapp-{{.Env}}-{{.Date "2006.01.02"}}`.
+type indexPatternData struct {
+	Env string
+	t   time.Time
+}
+
+// Date formats the pattern's evaluation time using a Go reference layout.
+func (d indexPatternData) Date(layout string) string {
+	return d.t.Format(layout)
+}
+
+// renderIndexPattern evaluates an --index-pattern template against now,
+// shifted into tz and by offset (so "-1d" checks yesterday's index after a
+// midnight rollover).
+func renderIndexPattern(pattern string, tz *time.Location, offset time.Duration, env string, now time.Time) (string, error) {
+	tmpl, err := template.New("index-pattern").Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid index pattern %q: %w", pattern, err)
+	}
+
+	var buf bytes.Buffer
+	data := indexPatternData{Env: env, t: now.In(tz).Add(offset)}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render index pattern %q: %w", pattern, err)
+	}
+
+	return buf.String(), nil
+}
+
+// statsIndexPattern returns the portion of an --index-pattern template
+// before its first Go template action, plus a trailing wildcard, e.g.
+// `myapp-{{.Date "2006.01.02"}}` becomes `myapp-*`. This lets the
+// _cat/indices-based metrics in stats.go follow whatever --index-pattern or
+// --config.file rules are configured instead of requiring a separate,
+// possibly stale --es-index-prefix.
+func statsIndexPattern(pattern string) string {
+	if i := strings.Index(pattern, "{{"); i >= 0 {
+		pattern = pattern[:i]
+	}
+	return pattern + "*"
+}
+
+// parseDateOffset parses a duration like "-1d", "2h", or "30m". The "d" unit
+// is not understood by time.ParseDuration, so it's handled separately.
+func parseDateOffset(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid date offset %q: %w", s, err)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date offset %q: %w", s, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderIndexPattern(t *testing.T) {
+	utc, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("failed to load UTC: %v", err)
+	}
+	now := time.Date(2026, time.July, 29, 12, 0, 0, 0, utc)
+
+	tests := []struct {
+		name    string
+		pattern string
+		tz      *time.Location
+		offset  time.Duration
+		env     string
+		now     time.Time
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "static pattern",
+			pattern: "myapp-logs",
+			tz:      utc,
+			now:     now,
+			want:    "myapp-logs",
+		},
+		{
+			name:    "date template",
+			pattern: `myapp-{{.Date "2006.01.02"}}`,
+			tz:      utc,
+			now:     now,
+			want:    "myapp-2026.07.29",
+		},
+		{
+			name:    "env and date template",
+			pattern: `myapp-{{.Env}}-{{.Date "2006.01.02"}}`,
+			tz:      utc,
+			env:     "prod",
+			now:     now,
+			want:    "myapp-prod-2026.07.29",
+		},
+		{
+			name:    "date offset shifts before rendering",
+			pattern: `myapp-{{.Date "2006.01.02"}}`,
+			tz:      utc,
+			offset:  -24 * time.Hour,
+			now:     now,
+			want:    "myapp-2026.07.28",
+		},
+		{
+			name:    "invalid template",
+			pattern: `myapp-{{.Date`,
+			tz:      utc,
+			now:     now,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderIndexPattern(tt.pattern, tt.tz, tt.offset, tt.env, tt.now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("renderIndexPattern(%q) = %q, want error", tt.pattern, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderIndexPattern(%q) returned error: %v", tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("renderIndexPattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateOffset(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "0", want: 0},
+		{in: "-1d", want: -24 * time.Hour},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "2h", want: 2 * time.Hour},
+		{in: "30m", want: 30 * time.Minute},
+		{in: "not-a-duration", wantErr: true},
+		{in: "xd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDateOffset(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDateOffset(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDateOffset(%q) returned error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseDateOffset(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsIndexPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "myapp-logs", want: "myapp-logs*"},
+		{pattern: `myapp-{{.Date "2006.01.02"}}`, want: "myapp-*"},
+		{pattern: `myapp-{{.Env}}-{{.Date "2006.01.02"}}`, want: "myapp-*"},
+	}
+
+	for _, tt := range tests {
+		if got := statsIndexPattern(tt.pattern); got != tt.want {
+			t.Errorf("statsIndexPattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// catIndexRow mirrors the fields we use out of a _cat/indices?format=json row.
+type catIndexRow struct {
+	Index       string `json:"index"`
+	Health      string `json:"health"`
+	DocsCount   string `json:"docs.count"`
+	DocsDeleted string `json:"docs.deleted"`
+	StoreSize   string `json:"store.size"`
+	Pri         string `json:"pri"`
+	Rep         string `json:"rep"`
+}
+
+// collectIndexStats queries _cat/indices once for every index matching one
+// of statsPatterns (each already wildcarded, see statsIndexPattern) and
+// returns the raw rows for the caller to turn into metrics.
+func collectIndexStats(ctx context.Context, client *elasticsearch.Client, statsPatterns []string) ([]catIndexRow, error) {
+	req := esapi.CatIndicesRequest{
+		Index:  statsPatterns,
+		Format: "json",
+		Bytes:  "b",
+	}
+
+	resp, err := req.Do(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("cat indices request returned an error: %s", resp.String())
+	}
+
+	var rows []catIndexRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to decode cat indices response: %w", err)
+	}
+
+	return rows, nil
+}
+
+// indexDocCount returns the document count for a single index, and false if
+// the index does not exist.
+func indexDocCount(ctx context.Context, client *elasticsearch.Client, indexName string) (int64, bool, error) {
+	req := esapi.CatIndicesRequest{
+		Index:  []string{indexName},
+		Format: "json",
+		Bytes:  "b",
+	}
+
+	resp, err := req.Do(ctx, client)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query doc count for %q: %w", indexName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return 0, false, nil
+	}
+	if resp.IsError() {
+		return 0, false, fmt.Errorf("cat indices request for %q returned an error: %s", indexName, resp.String())
+	}
+
+	var rows []catIndexRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return 0, false, fmt.Errorf("failed to decode cat indices response for %q: %w", indexName, err)
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+
+	count, err := strconv.ParseInt(rows[0].DocsCount, 10, 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("failed to parse doc count for %q: %w", indexName, err)
+	}
+	return count, true, nil
+}
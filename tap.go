@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// tap mode sources index names from mirrored traffic (a goreplay
+// --output-file recording, or a goreplay --output-tcp stream) instead of a
+// static --es-index-prefix, so drift between "indices the app tried to
+// write" and "indices that actually exist" shows up even when indices are
+// created dynamically by producers.
+
+const (
+	goreplayPayloadSeparator = "\n🐵🙊🙈\n"
+	maxGoreplayRecordBytes   = 8 << 20
+	maxBulkActionLineBytes   = 1 << 20
+)
+
+var (
+	indicesObservedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "elasticsearch_indices_observed_total",
+			Help: "Number of times an index name was observed in mirrored traffic",
+		},
+		[]string{"index_name"},
+	)
+	indicesObservedMissingTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "elasticsearch_indices_observed_missing_total",
+			Help: "Number of times an index observed in mirrored traffic did not exist in Elasticsearch",
+		},
+		[]string{"index_name"},
+	)
+
+	bulkIndexActionRe = regexp.MustCompile(`"_index"\s*:\s*"([^"]+)"`)
+)
+
+func init() {
+	prometheus.MustRegister(indicesObservedTotal, indicesObservedMissingTotal)
+}
+
+// runTap implements the `esindex_exporter tap` subcommand.
+func runTap(args []string) {
+	fs := flag.NewFlagSet("tap", flag.ExitOnError)
+	esURI := fs.String("es-uri", "", "Elasticsearch URI to verify observed indices against (required)")
+	inputFile := fs.String("input-file", "", "Path to a goreplay --output-file recording to replay")
+	listenAddr := fs.String("listen-addr", "", "TCP address to accept a goreplay --output-tcp stream on, e.g. :28020")
+	listenPort := fs.Int("listen-port", 9185, "Port to serve /metrics on")
+	timeoutStr := fs.String("timeout", "5s", "Timeout for each index-existence check")
+	fs.Parse(args)
+
+	if *esURI == "" || (*inputFile == "" && *listenAddr == "") {
+		fmt.Println("Error: --es-uri and one of --input-file or --listen-addr are required.")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	timeout, err := time.ParseDuration(*timeoutStr)
+	if err != nil {
+		log.Fatalf("Error parsing timeout: %s", err)
+	}
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{*esURI}})
+	if err != nil {
+		log.Fatalf("Error creating the client: %s", err)
+	}
+
+	go startTapMetricsServer(*listenPort)
+
+	indexNames := make(chan string, 1024)
+	var verifyDone sync.WaitGroup
+	verifyDone.Add(1)
+	go func() {
+		defer verifyDone.Done()
+		verifyObservedIndices(client, timeout, indexNames)
+	}()
+
+	if *inputFile != "" {
+		f, err := os.Open(*inputFile)
+		if err != nil {
+			log.Fatalf("Error opening %q: %s", *inputFile, err)
+		}
+		defer f.Close()
+
+		if err := scanGoreplayRecords(f, indexNames); err != nil {
+			log.Fatalf("Error replaying %q: %s", *inputFile, err)
+		}
+		close(indexNames)
+		verifyDone.Wait()
+
+		// The replay is done, but the whole point of --input-file mode is to
+		// expose the resulting counters on /metrics, so keep the process
+		// alive for that server to be scraped instead of exiting.
+		log.Printf("Finished replaying %q; serving /metrics until interrupted", *inputFile)
+		select {}
+	}
+
+	if err := tapListen(*listenAddr, indexNames); err != nil {
+		log.Fatalf("Error listening on %q: %s", *listenAddr, err)
+	}
+}
+
+// verifyObservedIndices checks every index name seen on indexNames against
+// Elasticsearch and updates the observed/missing counters.
+func verifyObservedIndices(client *elasticsearch.Client, timeout time.Duration, indexNames <-chan string) {
+	for indexName := range indexNames {
+		indicesObservedTotal.WithLabelValues(indexName).Inc()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		exists, err := checkIndexExists(ctx, client, indexName)
+		cancel()
+		if err != nil {
+			log.Printf("Error checking observed index %q: %s", indexName, err)
+			continue
+		}
+		if !exists {
+			indicesObservedMissingTotal.WithLabelValues(indexName).Inc()
+		}
+	}
+}
+
+// tapListen accepts goreplay --output-tcp connections and streams each one
+// through scanGoreplayRecords.
+func tapListen(addr string, indexNames chan<- string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", addr, err)
+	}
+	defer ln.Close()
+	log.Printf("Listening for goreplay traffic on %s", addr)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := scanGoreplayRecords(conn, indexNames); err != nil {
+				log.Printf("Error reading goreplay stream from %s: %s", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// scanGoreplayRecords splits r on goreplay's payload separator and pushes
+// every index name it can extract onto indexNames. Records that fail to
+// parse (partial writes, truncated connections) are skipped rather than
+// aborting the scan, as are oversized records: bufio.Scanner's own ErrTooLong
+// would otherwise abort the whole scan (and, via --input-file, the process)
+// on a single malformed record.
+func scanGoreplayRecords(r io.Reader, indexNames chan<- string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxGoreplayRecordBytes+64*1024)
+	scanner.Split(splitGoreplayRecords)
+
+	for scanner.Scan() {
+		token := scanner.Bytes()
+		if len(token) >= maxGoreplayRecordBytes {
+			log.Printf("Skipping oversized goreplay record (%d bytes)", len(token))
+			continue
+		}
+		indexName, ok := parseGoreplayRecord(token)
+		if !ok {
+			continue
+		}
+		indexNames <- indexName
+	}
+	return scanner.Err()
+}
+
+func splitGoreplayRecords(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.Index(data, []byte(goreplayPayloadSeparator)); i >= 0 {
+		return i + len(goreplayPayloadSeparator), data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	// No separator found within a record-sized window: rather than asking
+	// for more data and risking bufio.Scanner's own ErrTooLong (which would
+	// abort the whole scan), give up on this record as a token of its own so
+	// the scan can resync on the next separator.
+	if len(data) >= maxGoreplayRecordBytes {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseGoreplayRecord decodes a single goreplay record (a type byte, a
+// metadata line, then a raw HTTP request) and extracts the target index
+// name from an ES bulk or single-document write.
+func parseGoreplayRecord(record []byte) (string, bool) {
+	lines := bytes.SplitN(record, []byte("\n"), 2)
+	if len(lines) < 2 {
+		return "", false
+	}
+
+	meta := bytes.Fields(lines[0])
+	if len(meta) == 0 {
+		return "", false
+	}
+	// Only requests ('1') and replayed requests ('3') carry the index name
+	// we care about; responses ('2') don't.
+	switch meta[0][0] {
+	case '1', '3':
+	default:
+		return "", false
+	}
+
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(lines[1])))
+	if err != nil {
+		return "", false
+	}
+	defer req.Body.Close()
+
+	return extractIndexName(req)
+}
+
+// extractIndexName pulls the target index out of an ES write request: the
+// first path segment of `PUT /<index>/_doc/<id>`-style requests, or the
+// first bulk action's `_index` field for `POST /_bulk`. Requests against
+// meta endpoints (`_search`, `_cluster/health`, `_cat/indices`, ...) aren't
+// index writes and are skipped; `_` is illegal as the first character of a
+// real index name.
+func extractIndexName(req *http.Request) (string, bool) {
+	path := strings.Trim(req.URL.Path, "/")
+	if path == "" {
+		return "", false
+	}
+
+	segments := strings.SplitN(path, "/", 2)
+	if segments[0] != "_bulk" {
+		if strings.HasPrefix(segments[0], "_") {
+			return "", false
+		}
+		return segments[0], true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBulkActionLineBytes))
+	if err != nil {
+		return "", false
+	}
+
+	match := bulkIndexActionRe.FindSubmatch(body)
+	if match == nil {
+		return "", false
+	}
+	return string(match[1]), true
+}
+
+func startTapMetricsServer(port int) {
+	http.Handle("/metrics", promhttp.Handler())
+
+	log.Printf("Starting tap metrics server on port %d", port)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
+		log.Fatalf("Failed to start tap metrics server: %s", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExtractIndexName(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		wantName  string
+		wantFound bool
+	}{
+		{
+			name:      "single document write",
+			method:    "PUT",
+			target:    "/myapp-2026.07.29/_doc/1",
+			wantName:  "myapp-2026.07.29",
+			wantFound: true,
+		},
+		{
+			name:      "bulk request",
+			method:    "POST",
+			target:    "/_bulk",
+			body:      `{"index":{"_index":"myapp-2026.07.29","_id":"1"}}` + "\n{\"field\":\"value\"}\n",
+			wantName:  "myapp-2026.07.29",
+			wantFound: true,
+		},
+		{
+			name:      "bulk request with no index action",
+			method:    "POST",
+			target:    "/_bulk",
+			body:      `{"field":"value"}` + "\n",
+			wantFound: false,
+		},
+		{
+			name:      "search meta endpoint is skipped",
+			method:    "GET",
+			target:    "/_search",
+			wantFound: false,
+		},
+		{
+			name:      "cluster health meta endpoint is skipped",
+			method:    "GET",
+			target:    "/_cluster/health",
+			wantFound: false,
+		},
+		{
+			name:      "root path has no index",
+			method:    "GET",
+			target:    "/",
+			wantFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			gotName, gotFound := extractIndexName(req)
+			if gotFound != tt.wantFound {
+				t.Fatalf("extractIndexName(%s %s) found = %v, want %v", tt.method, tt.target, gotFound, tt.wantFound)
+			}
+			if gotFound && gotName != tt.wantName {
+				t.Errorf("extractIndexName(%s %s) = %q, want %q", tt.method, tt.target, gotName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestSplitGoreplayRecords(t *testing.T) {
+	input := "first" + goreplayPayloadSeparator + "second" + goreplayPayloadSeparator + "third"
+
+	var tokens []string
+	data := []byte(input)
+	for {
+		advance, token, err := splitGoreplayRecords(data, true)
+		if err != nil {
+			t.Fatalf("splitGoreplayRecords returned error: %v", err)
+		}
+		if advance == 0 && token == nil {
+			break
+		}
+		tokens = append(tokens, string(token))
+		data = data[advance:]
+		if len(data) == 0 {
+			break
+		}
+	}
+
+	want := []string{"first", "second", "third"}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens %v, want %v", len(tokens), tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d = %q, want %q", i, tokens[i], want[i])
+		}
+	}
+}
+
+func TestSplitGoreplayRecordsOversizedRecordResyncs(t *testing.T) {
+	// No payload separator has arrived yet within a record-sized window of
+	// buffered data, as if the true separator (and the next record) is still
+	// further down the stream.
+	oversized := bytes.Repeat([]byte("x"), maxGoreplayRecordBytes)
+
+	advance, token, err := splitGoreplayRecords(oversized, false)
+	if err != nil {
+		t.Fatalf("splitGoreplayRecords returned error: %v", err)
+	}
+	if advance != len(oversized) || len(token) != len(oversized) {
+		t.Fatalf("splitGoreplayRecords did not give up on the oversized record: advance=%d tokenLen=%d", advance, len(token))
+	}
+
+	// Once the split consumes the oversized data, the scan should resync on
+	// the next separator rather than staying stuck.
+	rest := []byte(goreplayPayloadSeparator + "next")
+	advance, token, err = splitGoreplayRecords(rest, true)
+	if err != nil {
+		t.Fatalf("splitGoreplayRecords returned error: %v", err)
+	}
+	if string(token) != "" || advance != len(goreplayPayloadSeparator) {
+		t.Fatalf("splitGoreplayRecords did not resync on the payload separator: token=%q advance=%d", token, advance)
+	}
+}
+
+func TestScanGoreplayRecordsSkipsUnparsableAndOversizedRecords(t *testing.T) {
+	goodReq := "1 1 0\n" + "PUT /myapp-2026.07.29/_doc/1 HTTP/1.1\r\nHost: es\r\nContent-Length: 0\r\n\r\n"
+	oversized := "1 1 0\n" + strings.Repeat("x", maxGoreplayRecordBytes)
+
+	input := goodReq + goreplayPayloadSeparator + oversized + goreplayPayloadSeparator + "garbage" + goreplayPayloadSeparator + goodReq
+
+	indexNames := make(chan string, 8)
+	if err := scanGoreplayRecords(strings.NewReader(input), indexNames); err != nil {
+		t.Fatalf("scanGoreplayRecords returned error: %v", err)
+	}
+	close(indexNames)
+
+	var got []string
+	for name := range indexNames {
+		got = append(got, name)
+	}
+
+	want := []string{"myapp-2026.07.29", "myapp-2026.07.29"}
+	if len(got) != len(want) {
+		t.Fatalf("got index names %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("index name %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}